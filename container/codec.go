@@ -0,0 +1,246 @@
+package container
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Well known codec names, as written to the avro.codec header entry.
+const (
+	CodecNull      = "null"
+	CodecDeflate   = "deflate"
+	CodecSnappy    = "snappy"
+	CodecZStandard = "zstandard"
+	CodecBzip2     = "bzip2"
+)
+
+// Codec represents a block codec used to compress and decompress container file blocks.
+//
+// A Codec is shared by every Decoder, Encoder, and ParallelDecoder using it - RegisterCodec
+// keeps one instance per name rather than handing out a fresh one per caller - so Decode
+// must be safe to call concurrently from multiple goroutines with different src slices.
+// ParallelDecoder relies on this: its worker pool calls Decode on the same Codec instance
+// from every worker at once. Encode has no such requirement, since nothing in this package
+// calls it concurrently.
+type Codec interface {
+	// Encode compresses src, returning the compressed bytes.
+	Encode(src []byte) []byte
+
+	// Decode decompresses src, returning the decompressed bytes. Must be safe for
+	// concurrent use.
+	Decode(src []byte) ([]byte, error)
+}
+
+// streamCodec is implemented by codecs that can decompress incrementally, without first
+// materialising the whole decompressed block in memory. The Decoder prefers this over
+// Decode when available.
+type streamCodec interface {
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]Codec{
+		CodecNull:      nullCodec{},
+		CodecDeflate:   deflateCodec{},
+		CodecSnappy:    snappyCodec{},
+		CodecZStandard: newZStandardCodec(),
+		CodecBzip2:     bzip2Codec{},
+	}
+)
+
+// RegisterCodec registers a Codec under name, making it usable via WithCodec and recognised
+// by NewDecoder when found in a file's avro.codec header entry. Registering a codec under an
+// already known name replaces it.
+//
+// c is shared by every caller that resolves name afterwards, including concurrently from
+// ParallelDecoder's worker pool, so c.Decode must be safe for concurrent use; see the Codec
+// doc comment.
+func RegisterCodec(name string, c Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+
+	codecs[name] = c
+}
+
+func resolveCodec(name string) (Codec, bool) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+
+	if name == "" {
+		name = CodecNull
+	}
+
+	c, ok := codecs[name]
+	return c, ok
+}
+
+type nullCodec struct{}
+
+func (nullCodec) Encode(src []byte) []byte { return src }
+
+func (nullCodec) Decode(src []byte) ([]byte, error) { return src, nil }
+
+func (nullCodec) NewReader(r io.Reader) (io.Reader, error) { return r, nil }
+
+type deflateCodec struct{}
+
+func (deflateCodec) Encode(src []byte) []byte {
+	buf := &bytes.Buffer{}
+	w, _ := flate.NewWriter(buf, flate.DefaultCompression)
+	_, _ = w.Write(src)
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func (deflateCodec) Decode(src []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+func (deflateCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return flate.NewReader(r), nil
+}
+
+// snappyCodec implements the Avro spec's snappy framing: the snappy compressed block followed
+// by the big-endian CRC-32 checksum of the uncompressed data.
+type snappyCodec struct{}
+
+func (snappyCodec) Encode(src []byte) []byte {
+	compressed := snappy.Encode(nil, src)
+
+	dst := make([]byte, len(compressed)+4)
+	copy(dst, compressed)
+	binary.BigEndian.PutUint32(dst[len(compressed):], crc32.ChecksumIEEE(src))
+
+	return dst
+}
+
+func (snappyCodec) Decode(src []byte) ([]byte, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("snappy: block of length %d too short to contain a checksum", len(src))
+	}
+
+	body, checksum := src[:len(src)-4], src[len(src)-4:]
+
+	decoded, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if want := binary.BigEndian.Uint32(checksum); crc32.ChecksumIEEE(decoded) != want {
+		return nil, fmt.Errorf("snappy: checksum mismatch")
+	}
+
+	return decoded, nil
+}
+
+type zStandardCodec struct {
+	encoder *zstd.Encoder
+
+	decoders sync.Pool
+}
+
+func newZStandardCodec() *zStandardCodec {
+	enc, _ := zstd.NewWriter(nil)
+
+	c := &zStandardCodec{encoder: enc}
+	c.decoders.New = func() interface{} {
+		// WithDecoderConcurrency(1) keeps each pooled decoder single-threaded, so it
+		// spins up exactly one background goroutine instead of one per CPU.
+		dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		return dec
+	}
+
+	return c
+}
+
+func (c *zStandardCodec) Encode(src []byte) []byte {
+	return c.encoder.EncodeAll(src, nil)
+}
+
+// Decode borrows a decoder from the pool for the duration of the call, so concurrent
+// callers (e.g. ParallelDecoder's worker pool) never share one in-flight decoder.
+func (c *zStandardCodec) Decode(src []byte) ([]byte, error) {
+	dec := c.decoders.Get().(*zstd.Decoder)
+	defer c.decoders.Put(dec)
+
+	return dec.DecodeAll(src, nil)
+}
+
+// NewReader resets a decoder borrowed from the codec's pool rather than creating a new
+// *zstd.Decoder per block. A fresh *zstd.Decoder holds background goroutines that are only
+// released by Close, and nothing reading the stream it returns is in a position to close
+// it once it is handed back as a plain io.Reader, so calling zstd.NewReader per block
+// leaked a goroutine per block. Pooling instead of sharing one decoder also means two
+// readers returned by concurrent calls - e.g. two container.Decoders reading different
+// files at once - never race over the same decoder state: the returned reader puts its
+// decoder back on Read's first error (including io.EOF), so it must be read to completion
+// or abandoned, never reused directly by the caller.
+func (c *zStandardCodec) NewReader(r io.Reader) (io.Reader, error) {
+	dec := c.decoders.Get().(*zstd.Decoder)
+
+	if err := dec.Reset(r); err != nil {
+		c.decoders.Put(dec)
+		return nil, err
+	}
+
+	return &zstdPooledReader{decoder: dec, pool: &c.decoders}, nil
+}
+
+// zstdPooledReader returns its *zstd.Decoder to the codec's pool as soon as reading it
+// reaches an error (io.EOF on the ordinary end-of-block path, or otherwise), so the next
+// NewReader call can reuse it instead of allocating a new decoder.
+type zstdPooledReader struct {
+	decoder *zstd.Decoder
+	pool    *sync.Pool
+	done    bool
+}
+
+func (r *zstdPooledReader) Read(p []byte) (int, error) {
+	n, err := r.decoder.Read(p)
+	if err != nil && !r.done {
+		r.done = true
+		r.pool.Put(r.decoder)
+	}
+
+	return n, err
+}
+
+type bzip2Codec struct{}
+
+func (bzip2Codec) Encode(src []byte) []byte {
+	buf := &bytes.Buffer{}
+	w, _ := bzip2.NewWriter(buf, nil)
+	_, _ = w.Write(src)
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func (bzip2Codec) Decode(src []byte) ([]byte, error) {
+	r, err := bzip2.NewReader(bytes.NewReader(src), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+func (bzip2Codec) NewReader(r io.Reader) (io.Reader, error) {
+	return bzip2.NewReader(r, nil)
+}
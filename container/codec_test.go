@@ -0,0 +1,141 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCodecs_EncodeDecodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+	}{
+		{CodecNull, nullCodec{}},
+		{CodecDeflate, deflateCodec{}},
+		{CodecSnappy, snappyCodec{}},
+		{CodecZStandard, newZStandardCodec()},
+		{CodecBzip2, bzip2Codec{}},
+	}
+
+	src := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog"), 100)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.codec.Encode(src)
+
+			decoded, err := tt.codec.Decode(encoded)
+			if err != nil {
+				t.Fatalf("Decode returned an unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(decoded, src) {
+				t.Fatalf("Decode did not round-trip: got %d bytes, want %d", len(decoded), len(src))
+			}
+		})
+	}
+}
+
+func TestCodecs_StreamDecodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+	}{
+		{CodecNull, nullCodec{}},
+		{CodecDeflate, deflateCodec{}},
+		{CodecZStandard, newZStandardCodec()},
+		{CodecBzip2, bzip2Codec{}},
+	}
+
+	src := bytes.Repeat([]byte("streaming decode should match whole-block decode"), 100)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := tt.codec.(streamCodec)
+			if !ok {
+				t.Fatalf("%s does not implement streamCodec", tt.name)
+			}
+
+			encoded := tt.codec.Encode(src)
+
+			r, err := sc.NewReader(bytes.NewReader(encoded))
+			if err != nil {
+				t.Fatalf("NewReader returned an unexpected error: %v", err)
+			}
+
+			decoded, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatalf("reading the stream returned an unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(decoded, src) {
+				t.Fatalf("streamed decode did not round-trip: got %d bytes, want %d", len(decoded), len(src))
+			}
+		})
+	}
+}
+
+func TestSnappyCodec_Decode_ChecksumMismatch(t *testing.T) {
+	c := snappyCodec{}
+
+	encoded := c.Encode([]byte("hello"))
+	encoded[len(encoded)-1] ^= 0xff // corrupt the trailing CRC-32
+
+	if _, err := c.Decode(encoded); err == nil {
+		t.Fatal("expected a checksum mismatch error, got nil")
+	}
+}
+
+// TestZStandardCodec_ConcurrentStreamDecode exercises two streaming decodes through the
+// same zStandardCodec at once, each against its own distinct payload, which would corrupt
+// one or both streams if NewReader still handed back one shared *zstd.Decoder.
+func TestZStandardCodec_ConcurrentStreamDecode(t *testing.T) {
+	c := newZStandardCodec()
+
+	a := bytes.Repeat([]byte("aaaaaaaaaa"), 1000)
+	b := bytes.Repeat([]byte("bbbbbbbbbb"), 1000)
+	encodedA := c.Encode(a)
+	encodedB := c.Encode(b)
+
+	results := make(chan error, 2)
+	run := func(encoded, want []byte) {
+		r, err := c.NewReader(bytes.NewReader(encoded))
+		if err != nil {
+			results <- err
+			return
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			results <- err
+			return
+		}
+		if !bytes.Equal(got, want) {
+			results <- fmt.Errorf("concurrent stream decode did not round-trip")
+			return
+		}
+		results <- nil
+	}
+
+	go run(encodedA, a)
+	go run(encodedB, b)
+
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Fatalf("concurrent NewReader decode failed: %v", err)
+		}
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec("test-passthrough", nullCodec{})
+	t.Cleanup(func() { delete(codecs, "test-passthrough") })
+
+	c, ok := resolveCodec("test-passthrough")
+	if !ok {
+		t.Fatal("resolveCodec did not find the registered codec")
+	}
+	if _, ok := c.(nullCodec); !ok {
+		t.Fatalf("resolveCodec returned %T, want nullCodec", c)
+	}
+}
@@ -12,9 +12,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
 
 	"github.com/hamba/avro"
-	"github.com/hamba/avro/internal/bytesx"
 )
 
 const (
@@ -44,10 +45,17 @@ type Header struct {
 
 // Decoder reads and decodes Avro values from a container file.
 type Decoder struct {
-	reader      *avro.Reader
-	resetReader *bytesx.ResetReader
-	decoder     *avro.Decoder
-	sync        [16]byte
+	src          io.Reader
+	reader       *avro.Reader
+	schema       avro.Schema
+	readerSchema avro.Schema
+	codec        Codec
+	meta         map[string][]byte
+	sync         [16]byte
+
+	block    io.Reader
+	blockRaw *io.LimitedReader
+	decoder  *avro.Decoder
 
 	count int64
 }
@@ -70,22 +78,66 @@ func NewDecoder(r io.Reader) (*Decoder, error) {
 		return nil, err
 	}
 
-	decReader := bytesx.NewResetReader([]byte{})
-
-	// TODO: File Codecs
-	// codec, ok := codecs[string(h.Meta[codecKey])]
-	//if codec, ok := codecs[string(h.Meta[codecKey])]; !ok {
-	//	return nil, fmt.Errorf("file: unknown codec %s", string(h.Meta[codecKey]))
-	//}
+	codec, ok := resolveCodec(string(h.Meta[codecKey]))
+	if !ok {
+		return nil, fmt.Errorf("decoder: unknown codec %s", string(h.Meta[codecKey]))
+	}
 
 	return &Decoder{
-		reader:      reader,
-		resetReader: decReader,
-		decoder:     avro.NewDecoderForSchema(schema, decReader),
-		sync:        h.Sync,
+		src:    r,
+		reader: reader,
+		schema: schema,
+		codec:  codec,
+		meta:   h.Meta,
+		sync:   h.Sync,
 	}, nil
 }
 
+// NewDecoderWithSchema returns a new decoder that reads from reader r, projecting values
+// written with the file's writer schema into reader, a compatible reader schema.
+//
+// This allows the reader schema to have evolved from the writer schema: fields may be
+// reordered, added (if they declare a default), or removed, and types may be promoted
+// (e.g. int to long, string to bytes) per the Avro spec. It returns a *SchemaResolutionError
+// if reader cannot be resolved against the file's writer schema.
+func NewDecoderWithSchema(r io.Reader, reader avro.Schema) (*Decoder, error) {
+	d, err := NewDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveSchema(d.schema, reader); err != nil {
+		return nil, err
+	}
+
+	d.readerSchema = reader
+
+	return d, nil
+}
+
+// WriterSchema returns the schema the container file was written with.
+func (d *Decoder) WriterSchema() avro.Schema {
+	return d.schema
+}
+
+// ReaderSchema returns the schema values are projected into, or nil if the decoder was
+// created with NewDecoder and has no reader schema.
+func (d *Decoder) ReaderSchema() avro.Schema {
+	return d.readerSchema
+}
+
+// Schema returns the schema the container file was written with. It is an alias of
+// WriterSchema kept for symmetry with Metadata.
+func (d *Decoder) Schema() avro.Schema {
+	return d.schema
+}
+
+// Metadata returns the file header's meta map, including the reserved avro.schema and
+// avro.codec entries alongside any entries set on the encoder with WithMeta.
+func (d *Decoder) Metadata() map[string][]byte {
+	return d.meta
+}
+
 // HasNext determines if there is another value to read.
 func (d *Decoder) HasNext() bool {
 	if d.count <= 0 {
@@ -107,6 +159,13 @@ func (d *Decoder) Decode(v interface{}) error {
 	return d.decoder.Decode(v)
 }
 
+// Reader returns the decompressed stream of the block currently being read, or nil if
+// HasNext has not yet been called. It is intended for callers that want to consume the
+// raw bytes of a block directly instead of decoding values one at a time.
+func (d *Decoder) Reader() io.Reader {
+	return d.block
+}
+
 // Error returns the last reader error.
 func (d *Decoder) Error() error {
 	if d.reader.Error == io.EOF {
@@ -116,21 +175,105 @@ func (d *Decoder) Error() error {
 	return d.reader.Error
 }
 
+// readBlock finishes verifying the previous block, if any, then reads the header of the
+// next one and opens a decompressing reader over it. The block's raw bytes are read off the
+// underlying stream, and decompressed, incrementally as records are decoded from it, rather
+// than being buffered whole upfront when the codec supports streaming (including null, i.e.
+// no compression at all): memory usage is then bound by the size of a single record. A codec
+// without streaming support still has the whole compressed block read, and the whole
+// decompressed block held, in memory.
 func (d *Decoder) readBlock() int64 {
+	if d.block != nil {
+		if err := d.finishBlock(); err != nil {
+			d.reader.Error = err
+			return 0
+		}
+	}
+
 	count := d.reader.ReadLong()
 	size := d.reader.ReadLong()
+	if d.reader.Error != nil {
+		return 0
+	}
+
+	raw := &io.LimitedReader{R: avroByteReader{d.reader}, N: size}
+
+	block, err := d.openBlock(raw)
+	if err != nil {
+		d.reader.Error = fmt.Errorf("decoder: %v", err)
+		return count
+	}
+
+	d.blockRaw = raw
+	d.block = block
+	if d.readerSchema != nil {
+		d.decoder, err = avro.NewResolvingDecoder(d.schema, d.readerSchema, block)
+	} else {
+		d.decoder = avro.NewDecoderForSchema(d.schema, block)
+	}
+	if err != nil {
+		d.reader.Error = fmt.Errorf("decoder: %v", err)
+		return count
+	}
+
+	return count
+}
 
-	data := make([]byte, size)
-	d.reader.Read(data)
-	d.resetReader.Reset(data)
+// finishBlock discards any compressed bytes of the current block that its decompressor
+// didn't need to consume, so the underlying reader's cursor lands exactly on that block's
+// trailing sync marker, then reads and verifies that marker.
+func (d *Decoder) finishBlock() error {
+	if _, err := io.Copy(ioutil.Discard, d.blockRaw); err != nil {
+		return fmt.Errorf("decoder: %v", err)
+	}
 
 	var sync [16]byte
 	d.reader.Read(sync[:])
 	if d.sync != sync && d.reader.Error != io.EOF {
-		d.reader.Error = errors.New("decoder: invalid block")
+		return errors.New("decoder: invalid block")
 	}
 
-	return count
+	return d.reader.Error
+}
+
+// openBlock returns a reader over the decompressed contents of a block, streaming the
+// decompression when the codec supports it rather than decoding the whole block upfront.
+func (d *Decoder) openBlock(raw io.Reader) (io.Reader, error) {
+	if sc, ok := d.codec.(streamCodec); ok {
+		return sc.NewReader(raw)
+	}
+
+	data, err := ioutil.ReadAll(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := d.codec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(decoded), nil
+}
+
+// avroByteReader adapts an *avro.Reader, which reads a fixed number of raw bytes per call
+// and reports failure through its Error field, to the io.Reader interface expected by
+// codecs' streaming decompressors.
+type avroByteReader struct {
+	r *avro.Reader
+}
+
+func (a avroByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	a.r.Read(p)
+	if a.r.Error != nil {
+		return 0, a.r.Error
+	}
+
+	return len(p), nil
 }
 
 // EncoderFunc represents an configuration function for Encoder
@@ -143,14 +286,53 @@ func WithBlockLength(length int) EncoderFunc {
 	}
 }
 
+// WithBlockSize sets the maximum size, in bytes, of the uncompressed data accumulated
+// before a block is flushed. This bounds memory usage when streaming large payloads (or
+// many small ones) through the encoder, independently of WithBlockLength.
+func WithBlockSize(size int) EncoderFunc {
+	return func(e *Encoder) {
+		e.blockSize = size
+	}
+}
+
+// WithMeta sets a metadata entry in the file header, alongside the avro.schema and
+// avro.codec entries. Keys in the reserved avro.* namespace are rejected so callers cannot
+// corrupt the schema or codec entries; use WithCodec to set the codec instead.
+//
+// This is the standard way to tag a container file with provenance such as the producing
+// host, pipeline version, or capture time, and is readable back with Decoder.Metadata.
+// A reserved key is reported by NewEncoder as an error, not a panic, since the key is
+// typically only known at runtime (e.g. sourced from config or user input).
+func WithMeta(key string, value []byte) EncoderFunc {
+	return func(e *Encoder) {
+		e.meta[key] = value
+	}
+}
+
+// WithCodec sets the codec used to compress blocks on the encoder.
+//
+// The name is written to the avro.codec header entry so that any reader, in this module
+// or another Avro implementation, knows how to decompress the blocks. An unregistered name
+// is reported by NewEncoder as an error, not a panic, since the name is typically only
+// known at runtime (e.g. sourced from config or user input).
+func WithCodec(name string) EncoderFunc {
+	return func(e *Encoder) {
+		e.codecName = name
+	}
+}
+
 // Encoder writes Avro container file to an output stream.
 type Encoder struct {
-	writer  *avro.Writer
-	buf     *bytes.Buffer
-	encoder *avro.Encoder
-	sync    [16]byte
+	writer    *avro.Writer
+	buf       *bytes.Buffer
+	encoder   *avro.Encoder
+	codec     Codec
+	codecName string
+	meta      map[string][]byte
+	sync      [16]byte
 
 	blockLength int
+	blockSize   int
 	count       int
 }
 
@@ -163,22 +345,15 @@ func NewEncoder(s string, w io.Writer, opts ...EncoderFunc) (*Encoder, error) {
 
 	writer := avro.NewWriter(w, 512)
 
-	header := Header{
-		Magic: magicBytes,
-		Meta: map[string][]byte{
-			schemaKey: []byte(schema.String()),
-		},
-	}
-	_, _ = rand.Read(header.Sync[:])
-	writer.WriteVal(HeaderSchema, header)
-
 	buf := &bytes.Buffer{}
 
 	e := &Encoder{
 		writer:      writer,
 		buf:         buf,
 		encoder:     avro.NewEncoderForSchema(schema, buf),
-		sync:        header.Sync,
+		codec:       nullCodec{},
+		codecName:   CodecNull,
+		meta:        map[string][]byte{},
 		blockLength: 100,
 	}
 
@@ -186,6 +361,31 @@ func NewEncoder(s string, w io.Writer, opts ...EncoderFunc) (*Encoder, error) {
 		opt(e)
 	}
 
+	codec, ok := resolveCodec(e.codecName)
+	if !ok {
+		return nil, fmt.Errorf("container: unknown codec %s", e.codecName)
+	}
+	e.codec = codec
+
+	for key := range e.meta {
+		if strings.HasPrefix(key, "avro.") {
+			return nil, fmt.Errorf("container: %q is in the reserved avro.* metadata namespace", key)
+		}
+	}
+
+	header := Header{
+		Magic: magicBytes,
+		Meta:  e.meta,
+	}
+	header.Meta[schemaKey] = []byte(schema.String())
+	header.Meta[codecKey] = []byte(e.codecName)
+	_, _ = rand.Read(header.Sync[:])
+	writer.WriteVal(HeaderSchema, header)
+	e.sync = header.Sync
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+
 	return e, nil
 }
 
@@ -195,8 +395,26 @@ func (e *Encoder) Encode(v interface{}) error {
 		return err
 	}
 
-	e.count++
-	if e.count >= e.blockLength {
+	return e.AddCount(1)
+}
+
+// Writer returns the io.Writer the encoder is currently accumulating the pending block's
+// Avro-encoded bytes into. It lets advanced callers stream pre-encoded Avro bytes - for
+// example, records copied from another Decoder's Reader() - straight into the block being
+// built, without decoding and re-encoding them through Encode and without ever holding a
+// whole block's worth of data in a caller-owned buffer. Every write of n complete records
+// through it must be followed by a call to AddCount(n), which is what drives the automatic
+// block flushing that Encode also relies on.
+func (e *Encoder) Writer() io.Writer {
+	return e.buf
+}
+
+// AddCount adds n to the number of records pending in the current block, flushing it once
+// WithBlockLength or WithBlockSize is reached. Encode calls this itself; it is only needed
+// directly when writing pre-encoded records through Writer.
+func (e *Encoder) AddCount(n int) error {
+	e.count += n
+	if e.count >= e.blockLength || (e.blockSize > 0 && e.buf.Len() >= e.blockSize) {
 		if err := e.writerBlock(); err != nil {
 			return err
 		}
@@ -219,9 +437,11 @@ func (e *Encoder) Close() error {
 }
 
 func (e *Encoder) writerBlock() error {
+	data := e.codec.Encode(e.buf.Bytes())
+
 	e.writer.WriteLong(int64(e.count))
-	e.writer.WriteLong(int64(e.buf.Len()))
-	e.writer.Write(e.buf.Bytes())
+	e.writer.WriteLong(int64(len(data)))
+	e.writer.Write(data)
 	e.writer.Write(e.sync[:])
 	e.count = 0
 	e.buf.Reset()
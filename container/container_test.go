@@ -0,0 +1,102 @@
+package container_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hamba/avro/container"
+)
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc, err := container.NewEncoder(`"string"`, buf)
+	if err != nil {
+		t.Fatalf("NewEncoder returned an unexpected error: %v", err)
+	}
+
+	want := []string{"foo", "bar", "baz"}
+	for _, v := range want {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode returned an unexpected error: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	dec, err := container.NewDecoder(buf)
+	if err != nil {
+		t.Fatalf("NewDecoder returned an unexpected error: %v", err)
+	}
+
+	var got []string
+	for dec.HasNext() {
+		var v string
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Decode returned an unexpected error: %v", err)
+		}
+		got = append(got, v)
+	}
+	if err := dec.Error(); err != nil {
+		t.Fatalf("Error returned an unexpected error: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("value %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// TestEncodeDecode_StreamedBlocks forces WithBlockSize well below the size of each encoded
+// value, so every block is flushed mid-stream rather than all at once at Close, and pairs
+// it with a compressing codec so decoding exercises the incremental decompression path in
+// Decoder.readBlock rather than reading a whole block into memory upfront.
+func TestEncodeDecode_StreamedBlocks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc, err := container.NewEncoder(`"bytes"`, buf,
+		container.WithCodec(container.CodecDeflate),
+		container.WithBlockSize(64),
+		container.WithBlockLength(1000),
+	)
+	if err != nil {
+		t.Fatalf("NewEncoder returned an unexpected error: %v", err)
+	}
+
+	payload := bytes.Repeat([]byte("x"), 256)
+	const n = 10
+	for i := 0; i < n; i++ {
+		if err := enc.Encode(payload); err != nil {
+			t.Fatalf("Encode returned an unexpected error: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	dec, err := container.NewDecoder(buf)
+	if err != nil {
+		t.Fatalf("NewDecoder returned an unexpected error: %v", err)
+	}
+
+	count := 0
+	for dec.HasNext() {
+		var v []byte
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Decode returned an unexpected error: %v", err)
+		}
+		if !bytes.Equal(v, payload) {
+			t.Fatalf("value %d did not round-trip", count)
+		}
+		count++
+	}
+	if err := dec.Error(); err != nil {
+		t.Fatalf("Error returned an unexpected error: %v", err)
+	}
+	if count != n {
+		t.Fatalf("got %d values, want %d", count, n)
+	}
+}
@@ -0,0 +1,52 @@
+package container_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hamba/avro/container"
+)
+
+func TestEncoder_WithMeta(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc, err := container.NewEncoder(`"string"`, buf, container.WithMeta("app.name", []byte("test")))
+	if err != nil {
+		t.Fatalf("NewEncoder returned an unexpected error: %v", err)
+	}
+	if err := enc.Encode("foo"); err != nil {
+		t.Fatalf("Encode returned an unexpected error: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	dec, err := container.NewDecoder(buf)
+	if err != nil {
+		t.Fatalf("NewDecoder returned an unexpected error: %v", err)
+	}
+
+	meta := dec.Metadata()
+	if got := string(meta["app.name"]); got != "test" {
+		t.Fatalf("Metadata()[%q] = %q, want %q", "app.name", got, "test")
+	}
+
+	if dec.Schema().String() == "" {
+		t.Fatal("Schema() returned an empty schema")
+	}
+}
+
+func TestNewEncoder_WithMeta_ReservedKeyIsAnError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_, err := container.NewEncoder(`"string"`, buf, container.WithMeta("avro.schema", []byte("nope")))
+	if err == nil {
+		t.Fatal("expected an error for a reserved avro.* metadata key, got nil")
+	}
+}
+
+func TestNewEncoder_WithCodec_UnknownNameIsAnError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_, err := container.NewEncoder(`"string"`, buf, container.WithCodec("not-a-real-codec"))
+	if err == nil {
+		t.Fatal("expected an error for an unregistered codec name, got nil")
+	}
+}
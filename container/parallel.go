@@ -0,0 +1,325 @@
+package container
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/hamba/avro"
+)
+
+// SeekBlock repositions the decoder in the underlying stream, which must implement
+// io.Seeker. It scans forward from offset for the file's 16-byte sync marker and resumes
+// decoding at the block header that follows it, so offset need not point exactly at a block
+// boundary. A block whose header begins exactly at offset is skipped, since the first sync
+// marker found is the one that ends that block; offset 0 resumes at the first block, after
+// the sync marker that ends the file header.
+func (d *Decoder) SeekBlock(offset int64) error {
+	seeker, ok := d.src.(io.Seeker)
+	if !ok {
+		return errors.New("decoder: underlying reader does not support seeking")
+	}
+
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("decoder: %w", err)
+	}
+
+	if err := scanForSync(d.src, d.sync); err != nil {
+		return err
+	}
+
+	d.reader = avro.NewReader(d.src, 1024)
+	d.count = 0
+	d.block = nil
+	d.decoder = nil
+
+	return nil
+}
+
+// scanForSync advances r past the next occurrence of sync, leaving r positioned right
+// after it, at the start of the following block header.
+func scanForSync(r io.Reader, sync [16]byte) error {
+	window := make([]byte, 0, 16)
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return fmt.Errorf("decoder: sync marker not found: %w", err)
+		}
+
+		window = append(window, b[0])
+		if len(window) > 16 {
+			window = window[1:]
+		}
+		if len(window) == 16 && bytes.Equal(window, sync[:]) {
+			return nil
+		}
+	}
+}
+
+// BlockInfo describes the location of a single block within a container file, as produced
+// by NewParallelDecoder's initial scan.
+type BlockInfo struct {
+	// Offset is the position, in the file, of the block's data, directly after its
+	// count and size header longs.
+	Offset int64
+	// Count is the number of records in the block.
+	Count int64
+	// Size is the size, in bytes, of the (possibly compressed) block data.
+	Size int64
+}
+
+// Result is a single value decoded by a ParallelDecoder, paired with the index of the
+// block it was read from so ordered consumers can restore file order.
+type Result struct {
+	Block int
+	Value interface{}
+	Err   error
+}
+
+// ParallelDecoder decodes the blocks of a container file concurrently across a pool of
+// workers, each building its own avro.Decoder per block, and delivers decoded values on a
+// channel. All workers share the one Codec resolved in NewParallelDecoder and call its
+// Decode concurrently, which the Codec interface requires every implementation to support.
+type ParallelDecoder struct {
+	ra      io.ReaderAt
+	schema  avro.Schema
+	codec   Codec
+	sync    [16]byte
+	index   []BlockInfo
+	workers int
+}
+
+// NewParallelDecoder scans the size bytes of the container file readable through r once to
+// build a block index, then returns a ParallelDecoder that will decode it using workers
+// goroutines.
+func NewParallelDecoder(r io.ReaderAt, size int64, workers int) (*ParallelDecoder, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	hdr, err := NewDecoder(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := scanBlockIndex(r, size, hdr.sync)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParallelDecoder{
+		ra:      r,
+		schema:  hdr.schema,
+		codec:   hdr.codec,
+		sync:    hdr.sync,
+		index:   index,
+		workers: workers,
+	}, nil
+}
+
+// BlockIndex returns the location of every block in the file, in file order.
+func (d *ParallelDecoder) BlockIndex() []BlockInfo {
+	return d.index
+}
+
+// Decode decodes every block concurrently across d's worker pool and delivers every value
+// on the returned channel, in file order, using per-block sequence numbers to reorder
+// results completed out of order. The channel is closed once every block has been decoded
+// or the first error is encountered: an error stops dispatching further blocks and aborts
+// any block already being decoded, instead of letting every other block run to completion.
+func (d *ParallelDecoder) Decode() <-chan Result {
+	out := make(chan Result, d.workers)
+
+	go func() {
+		defer close(out)
+
+		jobs := make(chan int)
+		stop := make(chan struct{})
+		var stopOnce sync.Once
+
+		results := make([]chan Result, len(d.index))
+		for i := range results {
+			results[i] = make(chan Result, 1)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < d.workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for block := range jobs {
+					d.decodeBlock(block, results[block], stop)
+				}
+			}()
+		}
+
+		go func() {
+			defer close(jobs)
+			for i := range d.index {
+				select {
+				case jobs <- i:
+				case <-stop:
+					return
+				}
+			}
+		}()
+
+	drain:
+		for _, ch := range results {
+			for r := range ch {
+				out <- r
+				if r.Err != nil {
+					stopOnce.Do(func() { close(stop) })
+					break drain
+				}
+			}
+		}
+
+		wg.Wait()
+	}()
+
+	return out
+}
+
+// decodeBlock decodes a single block, sending one Result per record (or a single Result
+// carrying the error that stopped it) to out, which it always closes before returning. It
+// checks stop before dispatching and before every send so that, once Decode has seen an
+// error and closed stop, an in-flight block abandons the rest of its work instead of
+// blocking on a result nobody will read.
+//
+// d.codec.Decode is called here from every worker goroutine at once, on d's single shared
+// Codec instance - safe only because the Codec interface requires Decode to support
+// concurrent use.
+func (d *ParallelDecoder) decodeBlock(block int, out chan<- Result, stop <-chan struct{}) {
+	defer close(out)
+
+	select {
+	case <-stop:
+		return
+	default:
+	}
+
+	info := d.index[block]
+
+	data := make([]byte, info.Size)
+	if _, err := d.ra.ReadAt(data, info.Offset); err != nil {
+		send(out, stop, Result{Block: block, Err: fmt.Errorf("decoder: %w", err)})
+		return
+	}
+
+	decoded, err := d.codec.Decode(data)
+	if err != nil {
+		send(out, stop, Result{Block: block, Err: fmt.Errorf("decoder: %w", err)})
+		return
+	}
+
+	dec := avro.NewDecoderForSchema(d.schema, bytes.NewReader(decoded))
+	for i := int64(0); i < info.Count; i++ {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			send(out, stop, Result{Block: block, Err: fmt.Errorf("decoder: %w", err)})
+			return
+		}
+
+		if !send(out, stop, Result{Block: block, Value: v}) {
+			return
+		}
+	}
+}
+
+// send delivers r on out, returning false instead if stop is closed first.
+func send(out chan<- Result, stop <-chan struct{}, r Result) bool {
+	select {
+	case out <- r:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// scanBlockIndex walks the raw bytes of a container file once, recording the location of
+// every block. It deliberately avoids the buffered avro.Reader used for sequential
+// decoding: that buffer may read ahead past a block boundary, which would make the byte
+// offsets it reports unreliable for later random access.
+func scanBlockIndex(ra io.ReaderAt, size int64, sync [16]byte) ([]BlockInfo, error) {
+	pos, err := headerLength(io.NewSectionReader(ra, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	var index []BlockInfo
+	for pos < size {
+		count, n, err := readLongAt(ra, pos)
+		if err != nil {
+			return nil, fmt.Errorf("decoder: %w", err)
+		}
+		pos += int64(n)
+
+		blockSize, n, err := readLongAt(ra, pos)
+		if err != nil {
+			return nil, fmt.Errorf("decoder: %w", err)
+		}
+		pos += int64(n)
+
+		index = append(index, BlockInfo{Offset: pos, Count: count, Size: blockSize})
+
+		pos += blockSize + 16 // skip the block data and its trailing sync marker
+	}
+
+	return index, nil
+}
+
+// headerLength returns the number of bytes the file header occupies, by decoding it through
+// an avro.Reader with a one-byte buffer, which never reads ahead of the header, and counting
+// the bytes that were read to do so.
+func headerLength(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	reader := avro.NewReader(cr, 1)
+
+	var h Header
+	reader.ReadVal(HeaderSchema, &h)
+	if reader.Error != nil {
+		return 0, fmt.Errorf("decoder: unexpected error: %v", reader.Error)
+	}
+
+	return cr.n, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readLongAt decodes a zig-zag varint encoded long starting at offset in ra, returning its
+// value and the number of bytes it occupied.
+func readLongAt(ra io.ReaderAt, offset int64) (int64, int, error) {
+	var (
+		b     [1]byte
+		shift uint
+		value uint64
+		n     int
+	)
+
+	for {
+		if _, err := ra.ReadAt(b[:], offset+int64(n)); err != nil {
+			return 0, 0, err
+		}
+		n++
+
+		value |= uint64(b[0]&0x7f) << shift
+		if b[0]&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+
+	return int64(value>>1) ^ -int64(value&1), n, nil
+}
@@ -0,0 +1,112 @@
+package container_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hamba/avro/container"
+)
+
+// buildContainer writes n int values, one per block, to get a file with a predictable
+// block layout to seek around and parallel-decode.
+func buildContainer(t *testing.T, n int) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	enc, err := container.NewEncoder(`"int"`, buf, container.WithBlockLength(1))
+	if err != nil {
+		t.Fatalf("NewEncoder returned an unexpected error: %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		if err := enc.Encode(i); err != nil {
+			t.Fatalf("Encode returned an unexpected error: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecoder_SeekBlock(t *testing.T) {
+	data := buildContainer(t, 5)
+
+	dec, err := container.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewDecoder returned an unexpected error: %v", err)
+	}
+
+	// Read the first two blocks sequentially; the seek below uses a separate decoder.
+	for i := 0; i < 2; i++ {
+		if !dec.HasNext() {
+			t.Fatalf("HasNext() returned false reading block %d", i)
+		}
+		var v int
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Decode returned an unexpected error: %v", err)
+		}
+	}
+
+	seekable, err := container.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewDecoder returned an unexpected error: %v", err)
+	}
+	if err := seekable.SeekBlock(0); err != nil {
+		t.Fatalf("SeekBlock returned an unexpected error: %v", err)
+	}
+
+	var got []int
+	for seekable.HasNext() {
+		var v int
+		if err := seekable.Decode(&v); err != nil {
+			t.Fatalf("Decode returned an unexpected error: %v", err)
+		}
+		got = append(got, v)
+	}
+	if err := seekable.Error(); err != nil {
+		t.Fatalf("Error returned an unexpected error: %v", err)
+	}
+
+	want := []int{0, 1, 2, 3, 4}
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("value %d: got %d, want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNewParallelDecoder(t *testing.T) {
+	const n = 8
+	data := buildContainer(t, n)
+
+	pd, err := container.NewParallelDecoder(bytes.NewReader(data), int64(len(data)), 4)
+	if err != nil {
+		t.Fatalf("NewParallelDecoder returned an unexpected error: %v", err)
+	}
+
+	if len(pd.BlockIndex()) != n {
+		t.Fatalf("BlockIndex() has %d entries, want %d (one value per block)", len(pd.BlockIndex()), n)
+	}
+
+	byBlock := map[int]int{}
+	for r := range pd.Decode() {
+		if r.Err != nil {
+			t.Fatalf("unexpected error decoding block %d: %v", r.Block, r.Err)
+		}
+		byBlock[r.Block] = r.Value.(int)
+	}
+
+	if len(byBlock) != n {
+		t.Fatalf("decoded %d values, want %d", len(byBlock), n)
+	}
+	for i := 0; i < n; i++ {
+		if byBlock[i] != i {
+			t.Fatalf("block %d decoded to %d, want %d", i, byBlock[i], i)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Package registry implements the Confluent Schema Registry wire format for single Avro
+values, as used throughout the Kafka ecosystem.
+
+Each message is encoded as a 1-byte magic (0x00), followed by a big-endian int32 schema ID,
+followed by the Avro binary encoding of the value using the schema that ID refers to. This is
+distinct from the Avro Object Container File format implemented by the parent container
+package: there is no file header, no blocks and no sync markers, since each message carries
+its own schema reference.
+
+See the Confluent documentation for the wire format and REST API:
+https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format
+*/
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/hamba/avro"
+)
+
+// SchemaRegistryClient resolves schemas to and from a schema registry.
+type SchemaRegistryClient interface {
+	// GetSchema returns the schema registered under id.
+	GetSchema(id uint32) (avro.Schema, error)
+
+	// RegisterSchema registers s under subject, returning its schema ID.
+	RegisterSchema(subject string, s avro.Schema) (uint32, error)
+}
+
+// HTTPClient is a SchemaRegistryClient backed by the Confluent Schema Registry REST API.
+type HTTPClient struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu    sync.RWMutex
+	cache map[uint32]avro.Schema
+}
+
+// NewHTTPClient returns an HTTPClient that talks to the schema registry at baseURL.
+func NewHTTPClient(baseURL string) *HTTPClient {
+	return &HTTPClient{
+		baseURL:    baseURL,
+		httpClient: http.DefaultClient,
+		cache:      map[uint32]avro.Schema{},
+	}
+}
+
+// GetSchema returns the schema registered under id, fetching it from the registry on the
+// first request and serving subsequent requests for the same id from an in-memory cache.
+func (c *HTTPClient) GetSchema(id uint32) (avro.Schema, error) {
+	c.mu.RLock()
+	s, ok := c.cache[id]
+	c.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	u := c.baseURL + "/schemas/ids/" + strconv.FormatUint(uint64(id), 10)
+	resp, err := c.httpClient.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry: unexpected status %s fetching schema %d", resp.Status, id)
+	}
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+
+	schema, err := avro.Parse(body.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cache[id] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+// RegisterSchema registers s under subject, returning its schema ID.
+func (c *HTTPClient) RegisterSchema(subject string, s avro.Schema) (uint32, error) {
+	body, err := json.Marshal(struct {
+		Schema string `json:"schema"`
+	}{Schema: s.String()})
+	if err != nil {
+		return 0, fmt.Errorf("registry: %w", err)
+	}
+
+	u := c.baseURL + "/subjects/" + url.PathEscape(subject) + "/versions"
+	resp, err := c.httpClient.Post(u, "application/vnd.schemaregistry.v1+json", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("registry: unexpected status %s registering subject %s", resp.Status, subject)
+	}
+
+	var respBody struct {
+		ID uint32 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return 0, fmt.Errorf("registry: %w", err)
+	}
+
+	return respBody.ID, nil
+}
+
+// MemoryClient is an in-memory SchemaRegistryClient, useful in tests.
+type MemoryClient struct {
+	mu      sync.RWMutex
+	schemas map[uint32]avro.Schema
+	nextID  uint32
+}
+
+// NewMemoryClient returns an empty MemoryClient.
+func NewMemoryClient() *MemoryClient {
+	return &MemoryClient{schemas: map[uint32]avro.Schema{}}
+}
+
+// GetSchema returns the schema registered under id.
+func (c *MemoryClient) GetSchema(id uint32) (avro.Schema, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, ok := c.schemas[id]
+	if !ok {
+		return nil, errors.New("registry: schema not found")
+	}
+
+	return s, nil
+}
+
+// RegisterSchema registers s, returning its schema ID. The subject is accepted for
+// interface compatibility but is not used to scope IDs, matching the single global ID
+// space exposed by GetSchema/RegisterSchema.
+func (c *MemoryClient) RegisterSchema(_ string, s avro.Schema) (uint32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, existing := range c.schemas {
+		if existing.String() == s.String() {
+			return id, nil
+		}
+	}
+
+	c.nextID++
+	c.schemas[c.nextID] = s
+
+	return c.nextID, nil
+}
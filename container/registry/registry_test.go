@@ -0,0 +1,105 @@
+package registry_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hamba/avro"
+	"github.com/hamba/avro/container/registry"
+)
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	client := registry.NewMemoryClient()
+	schema := avro.MustParse(`"string"`)
+
+	buf := &bytes.Buffer{}
+	enc, err := registry.NewRegistryEncoder("my-subject", schema, client, buf)
+	if err != nil {
+		t.Fatalf("NewRegistryEncoder returned an unexpected error: %v", err)
+	}
+
+	want := []string{"foo", "bar", "baz"}
+	for _, v := range want {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode returned an unexpected error: %v", err)
+		}
+	}
+
+	dec, err := registry.NewRegistryDecoder(client, buf)
+	if err != nil {
+		t.Fatalf("NewRegistryDecoder returned an unexpected error: %v", err)
+	}
+
+	for i, w := range want {
+		var got string
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("Decode %d returned an unexpected error: %v", i, err)
+		}
+		if got != w {
+			t.Fatalf("value %d: got %q, want %q", i, got, w)
+		}
+	}
+}
+
+// TestEncodeDecode_InterleavedSchemas is the scenario a Kafka topic carrying more than one
+// schema actually produces: messages for different schema IDs interleaved on the same
+// stream. A Decoder that keeps a separate buffered reader per schema ID, rather than
+// reading every message through one shared reader, desyncs here.
+func TestEncodeDecode_InterleavedSchemas(t *testing.T) {
+	client := registry.NewMemoryClient()
+	stringSchema := avro.MustParse(`"string"`)
+	intSchema := avro.MustParse(`"int"`)
+
+	buf := &bytes.Buffer{}
+	stringEnc, err := registry.NewRegistryEncoder("strings", stringSchema, client, buf)
+	if err != nil {
+		t.Fatalf("NewRegistryEncoder returned an unexpected error: %v", err)
+	}
+	intEnc, err := registry.NewRegistryEncoder("ints", intSchema, client, buf)
+	if err != nil {
+		t.Fatalf("NewRegistryEncoder returned an unexpected error: %v", err)
+	}
+
+	type msg struct {
+		str string
+		i   int32
+	}
+	want := []msg{{str: "one"}, {i: 1}, {str: "two"}, {i: 2}, {str: "three"}, {i: 3}}
+	for _, m := range want {
+		if m.str != "" {
+			if err := stringEnc.Encode(m.str); err != nil {
+				t.Fatalf("Encode returned an unexpected error: %v", err)
+			}
+			continue
+		}
+		if err := intEnc.Encode(m.i); err != nil {
+			t.Fatalf("Encode returned an unexpected error: %v", err)
+		}
+	}
+
+	dec, err := registry.NewRegistryDecoder(client, buf)
+	if err != nil {
+		t.Fatalf("NewRegistryDecoder returned an unexpected error: %v", err)
+	}
+
+	for i, m := range want {
+		if m.str != "" {
+			var got string
+			if err := dec.Decode(&got); err != nil {
+				t.Fatalf("Decode %d returned an unexpected error: %v", i, err)
+			}
+			if got != m.str {
+				t.Fatalf("message %d: got %q, want %q", i, got, m.str)
+			}
+			continue
+		}
+
+		var got int32
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("Decode %d returned an unexpected error: %v", i, err)
+		}
+		if got != m.i {
+			t.Fatalf("message %d: got %d, want %d", i, got, m.i)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hamba/avro"
+)
+
+// SchemaResolutionError indicates that a reader schema could not be resolved against a
+// writer schema, typically because a field the reader requires is missing from the writer
+// and has no default value.
+type SchemaResolutionError struct {
+	Err error
+}
+
+func (e *SchemaResolutionError) Error() string {
+	return fmt.Sprintf("container: cannot resolve reader schema: %v", e.Err)
+}
+
+func (e *SchemaResolutionError) Unwrap() error { return e.Err }
+
+// resolveSchema checks that reader can be resolved against writer, so that NewDecoderWithSchema
+// can fail fast with a typed error instead of only surfacing a problem once the first block
+// is decoded.
+//
+// It validates this by constructing a throwaway avro.NewResolvingDecoder rather than
+// re-implementing the Avro resolution rules (field reordering, added/removed fields, type
+// promotion, enum/union symbol matching) a second time: that decoder is the same resolver
+// readBlock uses to actually decode every block, so checking against it here guarantees
+// this upfront validation can never disagree with what decoding does later.
+func resolveSchema(writer, reader avro.Schema) error {
+	if _, err := avro.NewResolvingDecoder(writer, reader, bytes.NewReader(nil)); err != nil {
+		return &SchemaResolutionError{Err: err}
+	}
+
+	return nil
+}
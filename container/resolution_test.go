@@ -0,0 +1,79 @@
+package container_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hamba/avro"
+	"github.com/hamba/avro/container"
+)
+
+func TestNewDecoderWithSchema_Promotion(t *testing.T) {
+	writerSchema := `{"type":"record","name":"test","fields":[{"name":"a","type":"int"}]}`
+	readerSchema := avro.MustParse(`{"type":"record","name":"test","fields":[{"name":"a","type":"long"}]}`)
+
+	buf := &bytes.Buffer{}
+	enc, err := container.NewEncoder(writerSchema, buf)
+	if err != nil {
+		t.Fatalf("NewEncoder returned an unexpected error: %v", err)
+	}
+
+	type writerRec struct {
+		A int32 `avro:"a"`
+	}
+	if err := enc.Encode(writerRec{A: 42}); err != nil {
+		t.Fatalf("Encode returned an unexpected error: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	dec, err := container.NewDecoderWithSchema(buf, readerSchema)
+	if err != nil {
+		t.Fatalf("NewDecoderWithSchema returned an unexpected error: %v", err)
+	}
+
+	if dec.ReaderSchema().String() != readerSchema.String() {
+		t.Fatalf("ReaderSchema() did not return the schema passed to NewDecoderWithSchema")
+	}
+
+	type readerRec struct {
+		A int64 `avro:"a"`
+	}
+	if !dec.HasNext() {
+		t.Fatal("HasNext() returned false, want true")
+	}
+
+	var got readerRec
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("Decode returned an unexpected error: %v", err)
+	}
+	if got.A != 42 {
+		t.Fatalf("got A = %d, want 42", got.A)
+	}
+}
+
+func TestNewDecoderWithSchema_IncompatibleIsRejected(t *testing.T) {
+	writerSchema := `{"type":"record","name":"test","fields":[{"name":"a","type":"string"}]}`
+	readerSchema := avro.MustParse(`{"type":"record","name":"test","fields":[{"name":"a","type":"string"},{"name":"b","type":"int"}]}`)
+
+	buf := &bytes.Buffer{}
+	enc, err := container.NewEncoder(writerSchema, buf)
+	if err != nil {
+		t.Fatalf("NewEncoder returned an unexpected error: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close returned an unexpected error: %v", err)
+	}
+
+	_, err = container.NewDecoderWithSchema(buf, readerSchema)
+	if err == nil {
+		t.Fatal("expected an error for a reader field with no writer counterpart or default, got nil")
+	}
+
+	var resErr *container.SchemaResolutionError
+	if !errors.As(err, &resErr) {
+		t.Fatalf("got error of type %T, want *container.SchemaResolutionError", err)
+	}
+}